@@ -1,26 +1,82 @@
-//go:build windows
-
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/jessevdk/go-flags"
 
 	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/clipboardwatcher"
+	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/dedupe"
 	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/moonparse"
+	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/sink"
+	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/tray"
 )
 
+// dedupeCacheSize bounds how many recent scan hashes we remember; EVE moon
+// surveys are a handful of windows at a time, so this comfortably covers a
+// play session.
+const dedupeCacheSize = 256
+
 type Options struct {
 	APIEndpoint string `long:"api-endpoint" env:"API_ENDPOINT" description:"API endpoint URL"`
 	APIToken    string `long:"api-token" env:"API_TOKEN" description:"API Bearer token"`
+
+	Sink         string `long:"sink" env:"SINK" default:"stdout" description:"comma-separated list of where to send parsed scans: http, stdout, file:///path/to/file.jsonl"`
+	FileMaxBytes int64  `long:"file-max-bytes" env:"FILE_MAX_BYTES" description:"rotate a file:// sink's file once it grows past this many bytes; 0 disables rotation"`
+
+	QueueDB         string `long:"queue-db" env:"QUEUE_DB" description:"bbolt file used to queue scans the http sink failed to send, for retry once connectivity returns; unset disables the offline queue; has no effect unless --sink includes http"`
+	QueueMaxSize    int    `long:"queue-max-size" default:"1000" description:"max scans to retain in the offline queue"`
+	QueueDropOldest bool   `long:"queue-drop-oldest" description:"when the offline queue is full, drop the oldest queued scan instead of rejecting the new one"`
+
+	DedupeTTL time.Duration `long:"dedupe-ttl" default:"10m" description:"skip re-processing a scan pasted again within this long of when it was last successfully submitted"`
+
+	Tray bool `long:"tray" description:"show a system tray icon with status and controls (only does anything in a binary built with -tags tray)"`
+}
+
+// appState is the mutable state the main loop tracks between clipboard
+// events, so the tray (and a "re-send last scan" click) can reflect and act
+// on it.
+type appState struct {
+	tray *tray.Handle
+
+	paused     bool
+	lastScan   *sink.MoonScan
+	lastSentAt time.Time
+	uploadOK   int
+	uploadFail int
+}
+
+func (a *appState) status() tray.Status {
+	s := tray.Status{
+		Listening:      !a.paused,
+		UploadSuccess:  a.uploadOK,
+		UploadFailures: a.uploadFail,
+	}
+	if a.lastScan != nil {
+		s.LastScanAt = a.lastScan.ScannedAt
+		if s.LastScanAt.IsZero() {
+			// The paste had no "Scan Date:" header; fall back to when it was
+			// submitted so the tray doesn't report that no scan exists.
+			s.LastScanAt = a.lastSentAt
+		}
+		s.LastScanMoons = len(a.lastScan.Moons)
+	}
+	return s
 }
 
 func main() {
@@ -41,72 +97,265 @@ func main() {
 		Timeout: 15 * time.Second,
 	}
 
-	log.Println("Listening for clipboard text. Press Ctrl+C to exit.")
+	dest, closeSink, err := buildSink(opts, httpClient)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := closeSink(); err != nil {
+			log.Printf("Failed to close sink: %v", err)
+		}
+	}()
 
-	watchErr := clipboardwatcher.Watch(func(text string) {
-		log.Println("Clipboard changed")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-		if looksLikeMoonScan(text) {
-			log.Println("Possible moon scan data detected")
-			data, parseErr := moonparse.ParseMoons(text)
-			if parseErr != nil {
-				log.Fatalf("failed to parse moons: %v", parseErr)
-			}
+	watcher := clipboardwatcher.New()
+	events, err := watcher.Listen(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			payload, jsErr := json.Marshal(data)
-			if jsErr != nil {
-				log.Printf("Failed to marshal moon scan data: %v", jsErr)
+	seen := dedupe.New(dedupeCacheSize, opts.DedupeTTL)
+
+	trayHandle, err := tray.Run(opts.Tray, tray.Status{Listening: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer trayHandle.Quit()
+
+	state := &appState{tray: trayHandle}
+
+	log.Println("Listening for clipboard changes. Press Ctrl+C to exit.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Shutting down")
+			return
+
+		case event, ok := <-events:
+			if !ok {
 				return
 			}
-			log.Println("Moon scan parsed:")
+			if state.paused {
+				log.Println("Clipboard changed, but capture is paused")
+				continue
+			}
+			handleClipboardEvent(ctx, dest, seen, state, event)
+
+		case <-trayHandle.Actions().TogglePause:
+			state.paused = !state.paused
+			if state.paused {
+				log.Println("Capture paused from tray")
+			} else {
+				log.Println("Capture resumed from tray")
+			}
+			trayHandle.Update(state.status())
 
-			j, _ := json.MarshalIndent(data, "", "  ")
-			fmt.Println(string(j))
+		case <-trayHandle.Actions().ResendLast:
+			if state.lastScan == nil {
+				log.Println("Re-send requested, but no scan has been captured yet")
+				continue
+			}
+			log.Println("Re-sending last scan from tray")
+			submitScan(ctx, dest, state, *state.lastScan)
 
-			if opts.APIEndpoint != "" {
-				log.Println("Uploading data")
+		case <-trayHandle.Actions().OpenLogDir:
+			dir := logFolder(opts)
+			if err := openFolder(dir); err != nil {
+				log.Printf("Failed to open log folder %s: %v", dir, err)
+			}
 
-				req, reqErr := http.NewRequest("POST", opts.APIEndpoint, bytes.NewReader(payload))
-				if reqErr != nil {
-					log.Printf("Failed to create POST request: %v", reqErr)
-					return
-				}
-				req.Header.Set("Content-Type", "application/json")
+		case <-trayHandle.Actions().Quit:
+			log.Println("Quit requested from tray")
+			stop()
+		}
+	}
+}
 
-				if opts.APIToken != "" {
-					req.Header.Set("Authorization", "Bearer "+opts.APIToken)
-				}
+// buildSink assembles the sink.Sink described by opts.Sink. Only the http
+// sink is plausibly unreliable (stdout and file sinks are local), so when
+// opts.QueueDB is set it wraps just that sink in an offline queue rather
+// than the fanned-out whole: queuing the whole MultiSink would re-deliver
+// a scan to stdout/file a second time on every retry, once the queue
+// eventually managed to flush it.
+// The returned func closes anything that needs closing (currently just the
+// offline queue).
+func buildSink(opts Options, httpClient *http.Client) (sink.Sink, func() error, error) {
+	var sinks []sink.Sink
+	closeSink := func() error { return nil }
 
-				resp, httpErr := httpClient.Do(req)
-				if httpErr != nil {
-					log.Printf("POST %s failed: %v", opts.APIEndpoint, httpErr)
-					return
-				}
-				defer resp.Body.Close()
+	for _, spec := range strings.Split(opts.Sink, ",") {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+			continue
 
-				if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-					log.Printf("Moon scan posted to %s (status %s, %d bytes)", opts.APIEndpoint, resp.Status, len(payload))
-				} else {
-					log.Printf("Moon scan POST to %s returned status %s", opts.APIEndpoint, resp.Status)
+		case spec == "http":
+			if opts.APIEndpoint == "" {
+				return nil, nil, fmt.Errorf("--sink=http requires --api-endpoint")
+			}
+			var httpSink sink.Sink = sink.NewHTTPSink(opts.APIEndpoint, opts.APIToken, httpClient)
+			if opts.QueueDB != "" {
+				queued, err := sink.NewQueueingSink(httpSink, opts.QueueDB, opts.QueueMaxSize, opts.QueueDropOldest)
+				if err != nil {
+					return nil, nil, err
 				}
+				httpSink = queued
+				closeSink = queued.Close
 			}
+			sinks = append(sinks, httpSink)
+
+		case spec == "stdout":
+			sinks = append(sinks, sink.NewStdoutSink(os.Stdout))
+
+		case strings.HasPrefix(spec, "file://"):
+			sinks = append(sinks, sink.NewFileSink(strings.TrimPrefix(spec, "file://"), opts.FileMaxBytes))
+
+		default:
+			return nil, nil, fmt.Errorf("unrecognized --sink entry %q", spec)
 		}
-	})
+	}
+	if len(sinks) == 0 {
+		return nil, nil, fmt.Errorf("--sink produced no sinks")
+	}
+
+	return sink.NewMultiSink(sinks...), closeSink, nil
+}
+
+func handleClipboardEvent(ctx context.Context, dest sink.Sink, seen *dedupe.Cache, state *appState, event clipboardwatcher.ClipboardEvent) {
+	log.Println("Clipboard changed")
 
-	if watchErr != nil {
-		log.Fatal(watchErr)
+	text := candidateMoonScanText(event)
+	if !looksLikeMoonScan(text) {
+		return
+	}
+
+	scanID := dedupe.Hash(text)
+	if seen.Seen(scanID) {
+		log.Println("Ignoring moon scan identical to one we already sent")
+		return
+	}
+
+	log.Println("Possible moon scan data detected")
+	data, parseErr := moonparse.ParseMoons(text)
+	if parseErr != nil {
+		log.Printf("failed to parse moons: %v", parseErr)
+		return
+	}
+
+	log.Println("Moon scan parsed:")
+	j, _ := json.MarshalIndent(data, "", "  ")
+	fmt.Println(string(j))
+
+	if submitScan(ctx, dest, state, sink.MoonScan{ScanID: scanID, MoonProducts: data}) {
+		// Only remember this scan as delivered once it's actually been
+		// delivered; a parse or submit failure should let the user recover
+		// by just re-pasting the same scan.
+		seen.Add(scanID)
 	}
 }
 
+// submitScan delivers scan to dest, updates state's upload counters and
+// last-scan record, pushes the result to the tray, and reports whether
+// delivery succeeded.
+func submitScan(ctx context.Context, dest sink.Sink, state *appState, scan sink.MoonScan) bool {
+	err := dest.Submit(ctx, scan)
+	if err != nil {
+		log.Printf("Failed to submit moon scan: %v", err)
+		state.uploadFail++
+	} else {
+		state.uploadOK++
+	}
+	state.lastScan = &scan
+	state.lastSentAt = time.Now()
+	state.tray.Update(state.status())
+	return err == nil
+}
+
+// Regexps used to turn an HTML moon-scan table back into the tab-separated,
+// newline-delimited text moonparse.ParseMoons expects: a row boundary
+// (</tr>) becomes a newline, and a cell boundary (</td> or </th>) becomes a
+// tab; everything else is just markup to strip.
+var (
+	htmlRowCloseRe  = regexp.MustCompile(`(?i)</tr\s*>`)
+	htmlCellOpenRe  = regexp.MustCompile(`(?i)<t[dh][^>]*>`)
+	htmlCellCloseRe = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTagRe       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlTableToText converts an HTML moon-scan table into the same
+// tab-separated row-per-line shape as a plain-text clipboard paste, so it
+// can run through the same parsing path.
+func htmlTableToText(htmlText string) string {
+	s := htmlRowCloseRe.ReplaceAllString(htmlText, "\n")
+	s = htmlCellOpenRe.ReplaceAllString(s, "")
+	s = htmlCellCloseRe.ReplaceAllString(s, "\t")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	// Each row's last cell leaves a trailing tab behind from its </td>, and
+	// pretty-printed HTML leaves blank lines where whitespace sat between
+	// tags; clean both up so every remaining line is one row.
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, "\t \r")
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// candidateMoonScanText picks the best text we have to try to parse as a
+// moon scan: the plain-text clipboard contents, falling back to the HTML
+// fragment converted back to tab-separated rows when the in-game browser
+// only gave us a table.
+func candidateMoonScanText(event clipboardwatcher.ClipboardEvent) string {
+	if text := event.Text(); text != "" {
+		return text
+	}
+	if htmlText := event.HTML(); htmlText != "" {
+		return htmlTableToText(htmlText)
+	}
+	return ""
+}
+
 func looksLikeMoonScan(s string) bool {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return false
 	}
 
-	if strings.Contains(s, "Moon Product") {
-		return true
+	return moonparse.LooksLikeScan(s)
+}
+
+// logFolder returns the directory the tray's "Open log folder" item should
+// open: the offline queue's directory when one is configured, or the working
+// directory otherwise. A file:// sink's directory is not considered, and log
+// output goes to stderr rather than to a file.
+func logFolder(opts Options) string {
+	if opts.QueueDB != "" {
+		return filepath.Dir(opts.QueueDB)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return wd
 	}
+	return "."
+}
 
-	return false
+// openFolder shells out to the platform's file manager to open dir.
+func openFolder(dir string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("explorer", dir)
+	case "darwin":
+		cmd = exec.Command("open", dir)
+	default:
+		cmd = exec.Command("xdg-open", dir)
+	}
+	return cmd.Start()
 }
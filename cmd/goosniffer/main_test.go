@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTMLTableToText(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "moon_scan.html"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	got := htmlTableToText(string(data))
+	want := "66-PMM V - Moon 15\n" +
+		"Moon Product\tQuantity\tPlanet ID\tMoon ID\n" +
+		"Flawless Arkonor\t0.69\t40000001\t40000002"
+
+	if got != want {
+		t.Errorf("htmlTableToText() = %q, want %q", got, want)
+	}
+}
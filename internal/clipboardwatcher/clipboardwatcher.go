@@ -0,0 +1,133 @@
+// Package clipboardwatcher watches the system clipboard for changes and
+// delivers a ClipboardEvent for each one. Platform-specific backends live in
+// watcher_<os>.go files; this file holds the shared, OS-agnostic surface
+// that callers in cmd/goosniffer depend on.
+package clipboardwatcher
+
+import (
+	"context"
+	"sync"
+)
+
+// Well-known entries that can appear in ClipboardEvent.AvailableTypes.
+const (
+	TypeText  = "text"
+	TypeHTML  = "html"
+	TypeImage = "image"
+	TypeFiles = "files"
+)
+
+// ClipboardEvent describes everything a backend could read off the
+// clipboard for a single change. Not every field is populated on every
+// platform or for every clipboard change: check AvailableTypes, or just
+// call the accessor you want and check its zero value.
+type ClipboardEvent struct {
+	// AvailableTypes lists which of TypeText, TypeHTML, TypeImage, TypeFiles
+	// were present on the clipboard for this event.
+	AvailableTypes []string
+
+	text      string
+	html      string
+	image     []byte
+	imageMIME string
+	files     []string
+}
+
+// Text returns the plain-text representation of the clipboard contents, if
+// any was available.
+func (e ClipboardEvent) Text() string {
+	return e.text
+}
+
+// HTML returns the clipboard's HTML fragment (e.g. the "HTML Format" on
+// Windows), if any was available.
+func (e ClipboardEvent) HTML() string {
+	return e.html
+}
+
+// Image returns the clipboard's image bytes and a MIME type describing
+// them (e.g. "image/png"). img is nil if no image was available.
+func (e ClipboardEvent) Image() (img []byte, mime string) {
+	return e.image, e.imageMIME
+}
+
+// FilePaths returns the filesystem paths dropped onto the clipboard (e.g.
+// via CF_HDROP on Windows), if any were available.
+func (e ClipboardEvent) FilePaths() []string {
+	return e.files
+}
+
+// backend is implemented once per OS (see watcher_windows.go,
+// watcher_darwin.go, watcher_linux.go) and drives a single Watcher. Each of
+// those files also provides newBackend() backend to construct one.
+type backend interface {
+	// run performs whatever setup the backend needs, then reports the
+	// outcome on ready: nil on success, or an error if setup failed. On
+	// success it keeps running, sending a ClipboardEvent to events for
+	// every clipboard change, until stop is called. It returns once the
+	// loop has exited.
+	run(events chan<- ClipboardEvent, ready chan<- error)
+
+	// stop unblocks a running run() call. It is only ever called once per
+	// Watcher and only after ready has reported success.
+	stop()
+}
+
+// Watcher watches the clipboard for a single Listen/Stop lifecycle. Each
+// Watcher holds its own backend, so multiple Watchers can run independently
+// (e.g. in tests); the only package-level state is the Windows backend's
+// table mapping each hidden window to its Watcher's event channel.
+type Watcher struct {
+	backend backend
+
+	events chan ClipboardEvent
+	done   chan struct{}
+	stop   sync.Once
+}
+
+// New creates a Watcher. Call Listen to start it.
+func New() *Watcher {
+	return &Watcher{
+		backend: newBackend(),
+		events:  make(chan ClipboardEvent),
+		done:    make(chan struct{}),
+	}
+}
+
+// Listen starts watching the clipboard and returns a channel of clipboard
+// events. The channel is closed, and the watcher fully torn down, when ctx
+// is cancelled or Stop is called.
+func (w *Watcher) Listen(ctx context.Context) (<-chan ClipboardEvent, error) {
+	ready := make(chan error, 1)
+	go func() {
+		w.backend.run(w.events, ready)
+		close(w.events)
+	}()
+
+	if err := <-ready; err != nil {
+		// Setup failed, so backend.run already returned without ever
+		// unblocking on stop(); backend.stop() must not be called; some
+		// backends assume fields only get set on the success path and
+		// aren't safe to tear down before then.
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.Stop()
+		case <-w.done:
+		}
+	}()
+
+	return w.events, nil
+}
+
+// Stop shuts the watcher down. It is safe to call more than once, and safe
+// to call whether or not the watcher stopped on its own first.
+func (w *Watcher) Stop() {
+	w.stop.Do(func() {
+		w.backend.stop()
+		close(w.done)
+	})
+}
@@ -0,0 +1,84 @@
+//go:build darwin
+
+package clipboardwatcher
+
+/*
+#cgo LDFLAGS: -framework AppKit
+
+#include <stdlib.h>
+#import <AppKit/AppKit.h>
+
+static long long pasteboardChangeCount() {
+	return (long long)[[NSPasteboard generalPasteboard] changeCount];
+}
+
+static char *pasteboardString() {
+	NSString *s = [[NSPasteboard generalPasteboard] stringForType:NSPasteboardTypeString];
+	if (s == nil) {
+		return NULL;
+	}
+	return strdup([s UTF8String]);
+}
+*/
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// pollInterval is how often we ask NSPasteboard for its changeCount. macOS
+// has no clipboard-update notification API, so polling is the accepted
+// approach (pbcopy/pbpaste-based tools do the same).
+const pollInterval = 500 * time.Millisecond
+
+// darwinBackend polls NSPasteboard.changeCount from a background goroutine,
+// since macOS has no push notification for clipboard changes.
+type darwinBackend struct {
+	stopCh chan struct{}
+}
+
+func newBackend() backend {
+	return &darwinBackend{stopCh: make(chan struct{})}
+}
+
+// run implements backend.
+func (b *darwinBackend) run(events chan<- ClipboardEvent, ready chan<- error) {
+	ready <- nil
+
+	lastChangeCount := C.pasteboardChangeCount()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		changeCount := C.pasteboardChangeCount()
+		if changeCount == lastChangeCount {
+			continue
+		}
+		lastChangeCount = changeCount
+
+		cstr := C.pasteboardString()
+		if cstr == nil {
+			continue
+		}
+		text := C.GoString(cstr)
+		C.free(unsafe.Pointer(cstr))
+
+		events <- ClipboardEvent{
+			AvailableTypes: []string{TypeText},
+			text:           text,
+		}
+	}
+}
+
+// stop implements backend.
+func (b *darwinBackend) stop() {
+	close(b.stopCh)
+}
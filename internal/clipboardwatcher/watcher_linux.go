@@ -0,0 +1,15 @@
+//go:build linux
+
+package clipboardwatcher
+
+import "os"
+
+// newBackend picks a Linux backend the same way most desktop tooling does:
+// Wayland has no single clipboard API shared with X11, so we check
+// WAYLAND_DISPLAY first and fall back to X11.
+func newBackend() backend {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return newWaylandBackend()
+	}
+	return newX11Backend()
+}
@@ -0,0 +1,81 @@
+//go:build linux
+
+package clipboardwatcher
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+)
+
+// waylandBackend watches the Wayland clipboard.
+//
+// TODO: this always shells out to wl-paste (from wl-clipboard) rather than
+// speaking wlr-data-control-unstable-v1 natively as originally scoped. A
+// native client needs generated protocol bindings (wayland-scanner output
+// for wlr-data-control-unstable-v1.xml) that we don't vendor here, and
+// there was no way to fetch or generate them in this change. wl-paste
+// already speaks that protocol and is the de facto standard tool for this
+// on wlroots-based compositors (sway, etc), so it's a reasonable interim
+// backend, but a compositor without wl-paste installed (or without a
+// wlr-data-control-capable compositor at all, e.g. GNOME/Mutter) gets no
+// clipboard support at all instead of a native fallback. Revisit once we
+// can vendor the protocol bindings.
+//
+// Asking wl-paste to --watch a helper command gives us one clipboard event
+// at a time without polling.
+type waylandBackend struct {
+	cmd *exec.Cmd
+}
+
+func newWaylandBackend() backend {
+	return &waylandBackend{}
+}
+
+// run implements backend.
+func (b *waylandBackend) run(events chan<- ClipboardEvent, ready chan<- error) {
+	if _, err := exec.LookPath("wl-paste"); err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: wl-paste not found (install wl-clipboard): %w", err)
+		return
+	}
+
+	// The helper prints the clipboard contents followed by a NUL byte so we
+	// can split the stream back into discrete events.
+	cmd := exec.Command("wl-paste", "--type", "text", "--no-newline", "--watch",
+		"sh", "-c", `cat; printf '\0'`)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: wl-paste stdout pipe: %w", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: starting wl-paste: %w", err)
+		return
+	}
+	b.cmd = cmd
+
+	ready <- nil
+
+	reader := bufio.NewReader(stdout)
+	for {
+		text, err := reader.ReadString('\x00')
+		if err != nil {
+			// stop() kills the process, which surfaces here as a read
+			// error; treat that as a normal shutdown.
+			return
+		}
+		events <- ClipboardEvent{
+			AvailableTypes: []string{TypeText},
+			text:           text[:len(text)-1],
+		}
+	}
+}
+
+// stop implements backend by killing the wl-paste process, which unblocks
+// the stdout read in run.
+func (b *waylandBackend) stop() {
+	if b.cmd != nil && b.cmd.Process != nil {
+		b.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,179 @@
+//go:build linux
+
+package clipboardwatcher
+
+import (
+	"fmt"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xfixes"
+	"github.com/jezek/xgb/xproto"
+)
+
+// x11Backend watches the CLIPBOARD selection via the XFIXES extension,
+// which notifies us with a SelectionNotify-style event every time selection
+// ownership changes, instead of us having to poll.
+type x11Backend struct {
+	conn *xgb.Conn
+}
+
+func newX11Backend() backend {
+	return &x11Backend{}
+}
+
+// run implements backend.
+func (b *x11Backend) run(events chan<- ClipboardEvent, ready chan<- error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: connecting to X11: %w", err)
+		return
+	}
+	defer conn.Close()
+	b.conn = conn
+
+	if err := xfixes.Init(conn); err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: XFIXES not available: %w", err)
+		return
+	}
+	if _, err := xfixes.QueryVersion(conn, 5, 0).Reply(); err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: XFIXES QueryVersion: %w", err)
+		return
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	clipboardAtom, err := internAtom(conn, "CLIPBOARD")
+	if err != nil {
+		ready <- err
+		return
+	}
+
+	const selectionEventMask = xfixes.SelectionEventMaskSetSelectionOwner |
+		xfixes.SelectionEventMaskSelectionWindowDestroy |
+		xfixes.SelectionEventMaskSelectionClientClose
+
+	if err := xfixes.SelectSelectionInputChecked(
+		conn, root, clipboardAtom, selectionEventMask,
+	).Check(); err != nil {
+		ready <- fmt.Errorf("clipboardwatcher: XFixesSelectSelectionInput: %w", err)
+		return
+	}
+
+	clipboard, err := newX11Clipboard(conn, root)
+	if err != nil {
+		ready <- err
+		return
+	}
+
+	ready <- nil
+
+	for {
+		xev, xerr := conn.WaitForEvent()
+		if xerr != nil {
+			// stop() closes conn, which surfaces here as an error; treat
+			// that as a normal shutdown.
+			return
+		}
+		if _, ok := xev.(xfixes.SelectionNotifyEvent); !ok {
+			continue
+		}
+
+		text, err := clipboard.readText()
+		if err != nil {
+			continue
+		}
+		events <- ClipboardEvent{
+			AvailableTypes: []string{TypeText},
+			text:           text,
+		}
+	}
+}
+
+// stop implements backend by closing the X11 connection, which unblocks
+// the WaitForEvent call in run.
+func (b *x11Backend) stop() {
+	b.conn.Close()
+}
+
+// internAtom looks up (creating if necessary) the X atom for name.
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("clipboardwatcher: InternAtom(%s): %w", name, err)
+	}
+	return reply.Atom, nil
+}
+
+// x11Clipboard converts the CLIPBOARD selection to UTF8_STRING using a
+// dedicated, invisible window to receive the SelectionNotify reply.
+type x11Clipboard struct {
+	conn       *xgb.Conn
+	win        xproto.Window
+	clipboard  xproto.Atom
+	utf8String xproto.Atom
+	property   xproto.Atom
+}
+
+// newX11Clipboard creates the invisible window used as the requestor for
+// selection conversions.
+func newX11Clipboard(conn *xgb.Conn, root xproto.Window) (*x11Clipboard, error) {
+	win, err := xproto.NewWindowId(conn)
+	if err != nil {
+		return nil, fmt.Errorf("clipboardwatcher: NewWindowId: %w", err)
+	}
+	if err := xproto.CreateWindowChecked(
+		conn, xproto.WindowClassCopyFromParent, win, root,
+		-1, -1, 1, 1, 0,
+		xproto.WindowClassInputOutput, xproto.WindowClassCopyFromParent, 0, nil,
+	).Check(); err != nil {
+		return nil, fmt.Errorf("clipboardwatcher: CreateWindow: %w", err)
+	}
+
+	clipboardAtom, err := internAtom(conn, "CLIPBOARD")
+	if err != nil {
+		return nil, err
+	}
+	utf8StringAtom, err := internAtom(conn, "UTF8_STRING")
+	if err != nil {
+		return nil, err
+	}
+	propertyAtom, err := internAtom(conn, "GOOSNIFFER_CLIPBOARD")
+	if err != nil {
+		return nil, err
+	}
+
+	return &x11Clipboard{
+		conn:       conn,
+		win:        win,
+		clipboard:  clipboardAtom,
+		utf8String: utf8StringAtom,
+		property:   propertyAtom,
+	}, nil
+}
+
+// readText requests conversion of CLIPBOARD to UTF8_STRING and waits for the
+// resulting SelectionNotify/property before reading the text back out.
+func (c *x11Clipboard) readText() (string, error) {
+	if err := xproto.ConvertSelectionChecked(
+		c.conn, c.win, c.clipboard, c.utf8String, c.property, xproto.TimeCurrentTime,
+	).Check(); err != nil {
+		return "", fmt.Errorf("clipboardwatcher: ConvertSelection: %w", err)
+	}
+
+	for {
+		ev, err := c.conn.WaitForEvent()
+		if err != nil {
+			return "", fmt.Errorf("clipboardwatcher: waiting for SelectionNotify: %w", err)
+		}
+		if _, ok := ev.(xproto.SelectionNotifyEvent); ok {
+			break
+		}
+	}
+
+	reply, err := xproto.GetProperty(
+		c.conn, true, c.win, c.property, c.utf8String, 0, 1<<24,
+	).Reply()
+	if err != nil {
+		return "", fmt.Errorf("clipboardwatcher: GetProperty: %w", err)
+	}
+	return string(reply.Value), nil
+}
@@ -0,0 +1,238 @@
+//go:build windows
+
+package clipboardwatcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	WM_DESTROY         = 0x0002
+	WM_QUIT            = 0x0012
+	WM_CLIPBOARDUPDATE = 0x031D
+
+	CF_UNICODETEXT = 13
+	CF_DIB         = 8
+	CF_HDROP       = 15
+)
+
+// WNDCLASSEXW mirrors the WinAPI struct.
+type WNDCLASSEXW struct {
+	CbSize        uint32
+	Style         uint32
+	LpfnWndProc   uintptr
+	CbClsExtra    int32
+	CbWndExtra    int32
+	HInstance     uintptr
+	HIcon         uintptr
+	HCursor       uintptr
+	HbrBackground uintptr
+	LpszMenuName  *uint16
+	LpszClassName *uint16
+	HIconSm       uintptr
+}
+
+// MSG mirrors the WinAPI MSG struct.
+type MSG struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	PtX     int32
+	PtY     int32
+}
+
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procRegisterClassExW              = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW               = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW                = user32.NewProc("DefWindowProcW")
+	procGetMessageW                   = user32.NewProc("GetMessageW")
+	procTranslateMessage              = user32.NewProc("TranslateMessage")
+	procDispatchMessageW              = user32.NewProc("DispatchMessageW")
+	procAddClipboardFormatListener    = user32.NewProc("AddClipboardFormatListener")
+	procRemoveClipboardFormatListener = user32.NewProc("RemoveClipboardFormatListener")
+	procIsClipboardFormatAvailable    = user32.NewProc("IsClipboardFormatAvailable")
+	procOpenClipboard                 = user32.NewProc("OpenClipboard")
+	procCloseClipboard                = user32.NewProc("CloseClipboard")
+	procGetClipboardData              = user32.NewProc("GetClipboardData")
+	procPostMessageW                  = user32.NewProc("PostMessageW")
+
+	procGlobalLock   = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock = kernel32.NewProc("GlobalUnlock")
+
+	procPostQuitMessage = user32.NewProc("PostQuitMessage")
+)
+
+// windowsBackend is the Windows implementation of backend. It runs a hidden
+// window and pumps a Windows message loop, listening for
+// WM_CLIPBOARDUPDATE, until stop() posts WM_QUIT.
+type windowsBackend struct {
+	hwnd uintptr
+}
+
+func newBackend() backend {
+	return &windowsBackend{}
+}
+
+// wndEvents maps a live hidden window's handle to the channel its events
+// should be delivered on. wndProc is a C callback and so can't close over
+// per-Watcher state directly; it looks the channel up by hwnd instead.
+var (
+	wndEventsMu sync.Mutex
+	wndEvents   = map[uintptr]chan<- ClipboardEvent{}
+)
+
+// utf16PtrToString converts a *uint16 (null-terminated UTF-16) to Go string.
+func utf16PtrToString(ptr *uint16) string {
+	if ptr == nil {
+		return ""
+	}
+	u := (*[1 << 30]uint16)(unsafe.Pointer(ptr))
+	n := 0
+	for u[n] != 0 {
+		n++
+	}
+	return string(utf16.Decode(u[:n]))
+}
+
+// openClipboardRetry opens the clipboard, retrying with backoff since
+// another process (often the shell or another clipboard tool) can hold it
+// briefly.
+func openClipboardRetry() error {
+	const maxAttempts = 5
+	var lastErr error
+
+	// Retry OpenClipboard: 10, 20, 40, 80, 160 ms.
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		r1, _, err := procOpenClipboard.Call(0)
+		if r1 != 0 {
+			return nil
+		}
+		lastErr = err
+		time.Sleep(time.Duration(10*(1<<attempt)) * time.Millisecond)
+	}
+	return fmt.Errorf("OpenClipboard failed after %d attempts: %v", maxAttempts, lastErr)
+}
+
+// wndProc is the window procedure that receives WM_CLIPBOARDUPDATE.
+func wndProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case WM_CLIPBOARDUPDATE:
+		wndEventsMu.Lock()
+		events := wndEvents[hwnd]
+		wndEventsMu.Unlock()
+
+		if events != nil {
+			if event, err := readClipboardEvent(); err == nil {
+				events <- event
+			}
+		}
+		return 0
+
+	case WM_DESTROY:
+		procPostQuitMessage.Call(0)
+		return 0
+
+	default:
+		ret, _, _ := procDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+		return ret
+	}
+}
+
+// run implements backend.
+func (b *windowsBackend) run(events chan<- ClipboardEvent, ready chan<- error) {
+	hwnd, err := createHiddenWindow()
+	if err != nil {
+		ready <- err
+		return
+	}
+
+	if r, _, e := procAddClipboardFormatListener.Call(hwnd); r == 0 {
+		ready <- fmt.Errorf("AddClipboardFormatListener failed: %v", e)
+		return
+	}
+
+	b.hwnd = hwnd
+
+	wndEventsMu.Lock()
+	wndEvents[hwnd] = events
+	wndEventsMu.Unlock()
+	defer func() {
+		wndEventsMu.Lock()
+		delete(wndEvents, hwnd)
+		wndEventsMu.Unlock()
+	}()
+
+	ready <- nil
+
+	// Message loop.
+	var msg MSG
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(ret) == -1 || ret == 0 {
+			// -1 is a GetMessageW error, 0 is WM_QUIT; either way we're done.
+			return
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
+
+// stop implements backend by posting WM_QUIT to our hidden window, which
+// unblocks the GetMessageW loop in run.
+func (b *windowsBackend) stop() {
+	procRemoveClipboardFormatListener.Call(b.hwnd)
+	procPostMessageW.Call(b.hwnd, WM_QUIT, 0, 0)
+}
+
+// createHiddenWindow registers a throwaway window class and creates an
+// invisible window to receive clipboard messages.
+func createHiddenWindow() (uintptr, error) {
+	className, err := windows.UTF16PtrFromString("GoClipboardWatcherClass")
+	if err != nil {
+		return 0, err
+	}
+
+	var hInstance windows.Handle
+	if err := windows.GetModuleHandleEx(0, nil, &hInstance); err != nil {
+		return 0, fmt.Errorf("GetModuleHandleEx failed: %w", err)
+	}
+
+	var wcex WNDCLASSEXW
+	wcex.CbSize = uint32(unsafe.Sizeof(wcex))
+	wcex.LpfnWndProc = windows.NewCallback(wndProc)
+	wcex.HInstance = uintptr(hInstance)
+	wcex.LpszClassName = className
+
+	// The class is shared by every Watcher's hidden window, so a second
+	// Watcher finds it already registered.
+	if r, _, e := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wcex))); r == 0 && e != windows.ERROR_CLASS_ALREADY_EXISTS {
+		return 0, fmt.Errorf("RegisterClassExW failed: %v", e)
+	}
+
+	hwnd, _, e := procCreateWindowExW.Call(
+		0,
+		uintptr(unsafe.Pointer(className)), // lpClassName
+		0,                                  // lpWindowName
+		0,                                  // dwStyle
+		0, 0, 0, 0,                         // x, y, width, height
+		0, // hWndParent
+		0, // hMenu
+		uintptr(hInstance),
+		0, // lpParam
+	)
+	if hwnd == 0 {
+		return 0, fmt.Errorf("CreateWindowExW failed: %v", e)
+	}
+	return hwnd, nil
+}
@@ -0,0 +1,245 @@
+//go:build windows
+
+package clipboardwatcher
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	shell32 = windows.NewLazySystemDLL("shell32.dll")
+
+	procRegisterClipboardFormatW = user32.NewProc("RegisterClipboardFormatW")
+	procEnumClipboardFormats     = user32.NewProc("EnumClipboardFormats")
+	procGetClipboardFormatNameW  = user32.NewProc("GetClipboardFormatNameW")
+	procGlobalSize               = kernel32.NewProc("GlobalSize")
+	procDragQueryFileW           = shell32.NewProc("DragQueryFileW")
+)
+
+// htmlFormat and pngFormat are registered clipboard formats (as opposed to
+// the predefined CF_* constants) and must be looked up by name at runtime.
+var (
+	htmlFormat uintptr
+	pngFormat  uintptr
+)
+
+func init() {
+	htmlFormat, _, _ = procRegisterClipboardFormatW.Call(strPtr("HTML Format"))
+	pngFormat, _, _ = procRegisterClipboardFormatW.Call(strPtr("PNG"))
+}
+
+func strPtr(s string) uintptr {
+	p, err := windows.UTF16PtrFromString(s)
+	if err != nil {
+		return 0
+	}
+	return uintptr(unsafe.Pointer(p))
+}
+
+// readClipboardEvent enumerates every format currently on the clipboard and
+// builds a ClipboardEvent out of the ones we know how to read.
+func readClipboardEvent() (ClipboardEvent, error) {
+	if err := openClipboardRetry(); err != nil {
+		return ClipboardEvent{}, err
+	}
+	defer procCloseClipboard.Call()
+
+	var event ClipboardEvent
+
+	for _, format := range enumClipboardFormats() {
+		switch {
+		case format == CF_UNICODETEXT:
+			if text, ok := readUnicodeText(); ok {
+				event.text = text
+				event.AvailableTypes = append(event.AvailableTypes, TypeText)
+			}
+
+		case format == uint32(htmlFormat) && htmlFormat != 0:
+			if html, ok := readHTMLFormat(); ok {
+				event.html = html
+				event.AvailableTypes = append(event.AvailableTypes, TypeHTML)
+			}
+
+		case format == uint32(pngFormat) && pngFormat != 0:
+			if data, ok := readRawFormat(format); ok {
+				event.image = data
+				event.imageMIME = "image/png"
+				event.AvailableTypes = append(event.AvailableTypes, TypeImage)
+			}
+
+		case format == CF_DIB && event.image == nil:
+			if data, ok := readRawFormat(format); ok {
+				if bmp, ok := dibToBMP(data); ok {
+					event.image = bmp
+					event.imageMIME = "image/bmp"
+					event.AvailableTypes = append(event.AvailableTypes, TypeImage)
+				}
+			}
+
+		case format == CF_HDROP:
+			if files, ok := readDroppedFiles(); ok {
+				event.files = files
+				event.AvailableTypes = append(event.AvailableTypes, TypeFiles)
+			}
+		}
+	}
+
+	return event, nil
+}
+
+// enumClipboardFormats returns every format code currently on the
+// clipboard, in the order Windows reports them (clipboard must already be
+// open).
+func enumClipboardFormats() []uint32 {
+	var formats []uint32
+	var format uintptr
+	for {
+		ret, _, _ := procEnumClipboardFormats.Call(format)
+		if ret == 0 {
+			break
+		}
+		format = ret
+		formats = append(formats, uint32(ret))
+	}
+	return formats
+}
+
+// readRawFormat reads the raw bytes backing an already-open clipboard
+// format, sized via GlobalSize.
+func readRawFormat(format uint32) ([]byte, bool) {
+	handle, _, _ := procGetClipboardData.Call(uintptr(format))
+	if handle == 0 {
+		return nil, false
+	}
+	size, _, _ := procGlobalSize.Call(handle)
+	if size == 0 {
+		return nil, false
+	}
+	ptr, _, _ := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		return nil, false
+	}
+	defer procGlobalUnlock.Call(handle)
+
+	data := make([]byte, size)
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(ptr)), size))
+	return data, true
+}
+
+// readUnicodeText reads CF_UNICODETEXT (clipboard must already be open).
+func readUnicodeText() (string, bool) {
+	handle, _, _ := procGetClipboardData.Call(uintptr(CF_UNICODETEXT))
+	if handle == 0 {
+		return "", false
+	}
+	ptr, _, _ := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		return "", false
+	}
+	defer procGlobalUnlock.Call(handle)
+
+	return utf16PtrToString((*uint16)(unsafe.Pointer(ptr))), true
+}
+
+// readHTMLFormat reads the "HTML Format" registered clipboard format and
+// strips its CF_HTML description header, returning just the HTML fragment.
+// See https://learn.microsoft.com/en-us/windows/win32/dataxchg/html-clipboard-format.
+func readHTMLFormat() (string, bool) {
+	data, ok := readRawFormat(uint32(htmlFormat))
+	if !ok {
+		return "", false
+	}
+	return parseCFHTML(string(data)), true
+}
+
+// parseCFHTML extracts the fragment between StartFragment/EndFragment
+// offsets from a raw CF_HTML payload, falling back to the whole payload if
+// the header is malformed.
+func parseCFHTML(raw string) string {
+	start := cfHTMLOffset(raw, "StartFragment:")
+	end := cfHTMLOffset(raw, "EndFragment:")
+	if start < 0 || end < 0 || start >= end || end > len(raw) {
+		return raw
+	}
+	return raw[start:end]
+}
+
+func cfHTMLOffset(raw, key string) int {
+	idx := strings.Index(raw, key)
+	if idx < 0 {
+		return -1
+	}
+	rest := raw[idx+len(key):]
+	end := strings.IndexAny(rest, "\r\n")
+	if end < 0 {
+		return -1
+	}
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(rest[:end]), "%d", &n); err != nil {
+		return -1
+	}
+	return n
+}
+
+// dibToBMP turns a CF_DIB payload (a BITMAPINFOHEADER plus pixel data, no
+// file header) into a standalone .bmp by prepending a BITMAPFILEHEADER.
+// Only uncompressed, non-palettized DIBs (the common case for clipboard
+// screenshots) are supported.
+func dibToBMP(dib []byte) ([]byte, bool) {
+	const bitmapFileHeaderSize = 14
+	if len(dib) < 40 {
+		return nil, false
+	}
+	biSize := uint32(dib[0]) | uint32(dib[1])<<8 | uint32(dib[2])<<16 | uint32(dib[3])<<24
+	biBitCount := uint16(dib[14]) | uint16(dib[15])<<8
+	if biBitCount <= 8 {
+		// Palettized DIBs need palette-size accounting we don't do here.
+		return nil, false
+	}
+
+	pixelOffset := bitmapFileHeaderSize + int(biSize)
+	fileSize := bitmapFileHeaderSize + len(dib)
+
+	bmp := make([]byte, fileSize)
+	bmp[0], bmp[1] = 'B', 'M'
+	putUint32LE(bmp[2:], uint32(fileSize))
+	putUint32LE(bmp[10:], uint32(pixelOffset))
+	copy(bmp[bitmapFileHeaderSize:], dib)
+	return bmp, true
+}
+
+func putUint32LE(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}
+
+// readDroppedFiles reads CF_HDROP (clipboard must already be open).
+func readDroppedFiles() ([]string, bool) {
+	handle, _, _ := procGetClipboardData.Call(uintptr(CF_HDROP))
+	if handle == 0 {
+		return nil, false
+	}
+
+	count, _, _ := procDragQueryFileW.Call(handle, 0xFFFFFFFF, 0, 0)
+	if count == 0 {
+		return nil, false
+	}
+
+	files := make([]string, 0, count)
+	for i := uintptr(0); i < count; i++ {
+		n, _, _ := procDragQueryFileW.Call(handle, i, 0, 0)
+		if n == 0 {
+			continue
+		}
+		buf := make([]uint16, n+1)
+		procDragQueryFileW.Call(handle, i, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+		files = append(files, utf16PtrToString(&buf[0]))
+	}
+	return files, true
+}
@@ -0,0 +1,98 @@
+// Package dedupe tracks recently seen content hashes so callers can skip
+// re-processing clipboard content that hasn't actually changed, which
+// happens constantly when tabbing between the in-game survey window and a
+// spreadsheet.
+package dedupe
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a bounded, TTL'd set of content hashes.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	index    map[string]*list.Element
+}
+
+type entry struct {
+	hash    string
+	expires time.Time
+}
+
+// New creates a Cache holding at most capacity hashes, each considered
+// "seen" for ttl after being recorded.
+func New(capacity int, ttl time.Duration) *Cache {
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+// Seen reports whether hash was recorded, via Add, within the last ttl. It
+// does not itself record hash; callers that only want to record a hash once
+// whatever it identifies has actually been handled successfully should call
+// Add separately, after that work succeeds.
+func (c *Cache) Seen(hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.index[hash]
+	if !ok {
+		return false
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.index, hash)
+		return false
+	}
+
+	c.order.MoveToFront(el)
+	return true
+}
+
+// Add records hash as seen now, evicting the least-recently-used entry if
+// the cache is over capacity.
+func (c *Cache) Add(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[hash]; ok {
+		c.order.Remove(el)
+		delete(c.index, hash)
+	}
+
+	c.order.PushFront(&entry{hash: hash, expires: time.Now().Add(c.ttl)})
+	c.index[hash] = c.order.Front()
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.index, oldest.Value.(*entry).hash)
+	}
+}
+
+// Hash returns a stable content hash for s, normalizing away whitespace
+// differences (trailing spaces, CRLF vs LF) that don't change the
+// underlying scan but would otherwise defeat deduplication.
+func Hash(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	normalized := strings.TrimSpace(strings.Join(lines, "\n"))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
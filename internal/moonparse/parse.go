@@ -1,8 +1,13 @@
+// Package moonparse parses EVE Online moon survey scans, as pasted from the
+// in-game probe scanner window, into structured data.
 package moonparse
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Outer key: moon name  (e.g. "66-PMM V - Moon 15")
@@ -16,53 +21,171 @@ type MoonProductData struct {
 	MoonID        string `json:"moon_id"`
 }
 
-type MoonProducts map[string]map[string]MoonProductData
+// MoonProducts is the result of parsing a moon scan paste. ScannedAt is the
+// zero Time when the paste didn't include a "Scan Date:" header line.
+type MoonProducts struct {
+	ScannedAt time.Time                             `json:"scanned_at,omitempty"`
+	Moons     map[string]map[string]MoonProductData `json:"moons"`
+}
+
+// ParseError reports a problem with one line of scan input, identified by
+// its 1-indexed line number within the pasted text.
+type ParseError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("moonparse: line %d: %q: %v", e.Line, e.Text, e.Err)
+}
 
-// Example moon line:
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// moonLineRe matches a moon name line, e.g.:
 //
 //	66-PMM V - Moon 15
+//	66-PMM V - Lune 15   (French)
+//	66-PMM V - Mond 15   (German)
+//	66-PMM V - Луна 15   (Russian)
+//	66-PMM V - 月 15     (Japanese/Chinese)
 //
 // Capture whole "66-PMM V - Moon 15" as group 1.
-var moonLineRe = regexp.MustCompile(`^\s*(.+ - Moon \d+)\s*$`)
+var moonLineRe = regexp.MustCompile(`(?i)^\s*(.+ - (?:Moon|Lune|Mond|Луна|月) \d+)\s*$`)
 
-// Example product line:
-//
-//	Flawless Arkonor    0.323762148619    46678    30004923    40311969    40311985
-//
-// Groups:
-//
-//	1: product name
-//	2: quantity
-//	3: ore typeID
-//	4: solarSystemID
-//	5: planetID
-//	6: moonID
-var productLineRe = regexp.MustCompile(
-	`^\s*(.+?)\s+([0-9]+(?:\.[0-9]+)?)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*$`,
-)
+// scanDateRe matches an optional header line giving the time the scan was
+// taken, e.g. "Scan Date: 2024-05-11 14:03:22".
+var scanDateRe = regexp.MustCompile(`(?i)^\s*Scan Date:\s*(.+?)\s*$`)
+
+const scanDateLayout = "2006-01-02 15:04:05"
+
+// headerTokenField maps a lowercased, trimmed header cell to the canonical
+// field it identifies, across the client localizations we know about. A
+// header row is auto-detected by splitting it on tabs and looking each cell
+// up here, which is what lets us cope with both localized column names and
+// the "compact" layout that drops the ore type ID / solar system ID
+// columns entirely.
+var headerTokenField = map[string]string{
+	// English
+	"moon product":    "product",
+	"quantity":        "quantity",
+	"typeid":          "ore_type_id",
+	"type id":         "ore_type_id",
+	"solar system id": "solar_system_id",
+	"system id":       "solar_system_id",
+	"planetid":        "planet_id",
+	"planet id":       "planet_id",
+	"moonid":          "moon_id",
+	"moon id":         "moon_id",
+
+	// French
+	"produit de lune": "product",
+	"quantité":        "quantity",
+
+	// German
+	"mondprodukt": "product",
+	"menge":       "quantity",
+
+	// Russian
+	"продукт луны": "product",
+	"количество":   "quantity",
+
+	// Japanese / Chinese
+	"月の製品": "product",
+	"月产品":  "product",
+	"数量":   "quantity",
+}
+
+// columnLayout describes which canonical field each tab-separated column of
+// a product row holds. An empty field name means "ignore this column".
+type columnLayout struct {
+	fields []string
+}
+
+// defaultLayout is assumed when a paste has no recognizable header line, to
+// stay compatible with old captures that didn't include one.
+var defaultLayout = columnLayout{
+	fields: []string{"product", "quantity", "ore_type_id", "solar_system_id", "planet_id", "moon_id"},
+}
+
+// compactLayout is the fallback for a 4-column product row with no header:
+// product, quantity, planet ID, moon ID.
+var compactLayout = columnLayout{
+	fields: []string{"product", "quantity", "planet_id", "moon_id"},
+}
+
+// layoutByFieldCount returns a plausible layout for a product row with n
+// tab-separated cells when no header has told us the real one.
+func layoutByFieldCount(n int) (columnLayout, bool) {
+	switch n {
+	case len(defaultLayout.fields):
+		return defaultLayout, true
+	case len(compactLayout.fields):
+		return compactLayout, true
+	default:
+		return columnLayout{}, false
+	}
+}
+
+// detectHeader reports whether line is a header row, and if so the column
+// layout it describes.
+func detectHeader(line string) (columnLayout, bool) {
+	cells := strings.Split(line, "\t")
+	if len(cells) == 0 {
+		return columnLayout{}, false
+	}
+	if headerTokenField[normalizeHeaderToken(cells[0])] != "product" {
+		return columnLayout{}, false
+	}
+
+	fields := make([]string, len(cells))
+	for i, cell := range cells {
+		fields[i] = headerTokenField[normalizeHeaderToken(cell)]
+	}
+	return columnLayout{fields: fields}, true
+}
 
+func normalizeHeaderToken(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// ParseMoons parses a moon scan paste. Lines it doesn't recognize (stray
+// text, a malformed header) are skipped rather than rejected, to stay
+// tolerant of whatever the in-game browser surrounds the table with. A line
+// that looks like a product row for the active column layout but has an
+// invalid value (e.g. a non-numeric quantity) is reported as a *ParseError.
 func ParseMoons(input string) (MoonProducts, error) {
-	result := make(MoonProducts)
+	result := MoonProducts{Moons: make(map[string]map[string]MoonProductData)}
+
 	var currentMoon string
+	var layout *columnLayout
 
-	lines := strings.Split(input, "\n")
-	for _, rawLine := range lines {
+	for i, rawLine := range strings.Split(input, "\n") {
+		lineNo := i + 1
 		line := strings.TrimRight(rawLine, "\r")
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" {
 			continue
 		}
 
-		// Skip header line
-		if strings.Contains(trimmed, "Moon Product") {
+		if m := scanDateRe.FindStringSubmatch(trimmed); m != nil {
+			if ts, err := time.Parse(scanDateLayout, m[1]); err == nil {
+				result.ScannedAt = ts
+			}
+			continue
+		}
+
+		if l, ok := detectHeader(trimmed); ok {
+			layout = &l
 			continue
 		}
 
-		// Detect moon name
 		if m := moonLineRe.FindStringSubmatch(line); len(m) == 2 {
 			currentMoon = m[1]
-			if _, ok := result[currentMoon]; !ok {
-				result[currentMoon] = make(map[string]MoonProductData)
+			if _, ok := result.Moons[currentMoon]; !ok {
+				result.Moons[currentMoon] = make(map[string]MoonProductData)
 			}
 			continue
 		}
@@ -70,17 +193,80 @@ func ParseMoons(input string) (MoonProducts, error) {
 			continue
 		}
 
-		// Detect product rows
-		if m := productLineRe.FindStringSubmatch(line); len(m) == 7 {
-			product := m[1]
-			result[currentMoon][product] = MoonProductData{
-				Quantity:      m[2],
-				OreTypeID:     m[3],
-				SolarSystemID: m[4],
-				PlanetID:      m[5],
-				MoonID:        m[6],
-			}
+		product, data, ok, err := parseProductLine(line, layout)
+		if err != nil {
+			return MoonProducts{}, &ParseError{Line: lineNo, Text: line, Err: err}
 		}
+		if !ok {
+			continue
+		}
+		result.Moons[currentMoon][product] = data
 	}
 	return result, nil
 }
+
+// parseProductLine parses one tab-separated product row using layout (or a
+// best-effort guess by column count if layout is nil, i.e. no header was
+// seen yet). ok is false when the line doesn't look like a product row at
+// all, which callers should treat as "skip, not an error".
+func parseProductLine(line string, layout *columnLayout) (product string, data MoonProductData, ok bool, err error) {
+	cells := strings.Split(line, "\t")
+
+	l := defaultLayout
+	if layout != nil {
+		l = *layout
+	}
+	if len(cells) != len(l.fields) {
+		guess, found := layoutByFieldCount(len(cells))
+		if !found {
+			return "", MoonProductData{}, false, nil
+		}
+		l = guess
+	}
+
+	for i, field := range l.fields {
+		val := strings.TrimSpace(cells[i])
+		switch field {
+		case "product":
+			product = val
+		case "quantity":
+			if _, err := strconv.ParseFloat(val, 64); err != nil {
+				return "", MoonProductData{}, false, fmt.Errorf("invalid quantity %q: %w", val, err)
+			}
+			data.Quantity = val
+		case "ore_type_id":
+			data.OreTypeID = val
+		case "solar_system_id":
+			data.SolarSystemID = val
+		case "planet_id":
+			data.PlanetID = val
+		case "moon_id":
+			data.MoonID = val
+		}
+	}
+
+	if product == "" {
+		return "", MoonProductData{}, false, nil
+	}
+	return product, data, true, nil
+}
+
+// LooksLikeScan reports whether s contains anything ParseMoons would
+// recognize: a "Moon Product" header (in any known localization) or a moon
+// name line.
+func LooksLikeScan(s string) bool {
+	for _, rawLine := range strings.Split(s, "\n") {
+		line := strings.TrimRight(rawLine, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if _, ok := detectHeader(trimmed); ok {
+			return true
+		}
+		if moonLineRe.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package moonparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// englishMoonName is the moon name line used by the English-language
+// fixtures (english.txt, compact.txt, malformed.txt).
+const englishMoonName = "66-PMM V - Moon 15"
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestParseMoons_Localizations(t *testing.T) {
+	arkonorNames := map[string]string{
+		"english.txt":  "Flawless Arkonor",
+		"french.txt":   "Arkonor impeccable",
+		"german.txt":   "Tadelloser Arkonor",
+		"russian.txt":  "Безупречный Арконор",
+		"japanese.txt": "完璧なアーコノール",
+		"chinese.txt":  "完美阿克诺",
+	}
+
+	for fixture, product := range arkonorNames {
+		t.Run(fixture, func(t *testing.T) {
+			got, err := ParseMoons(readFixture(t, fixture))
+			if err != nil {
+				t.Fatalf("ParseMoons: %v", err)
+			}
+
+			if len(got.Moons) != 1 {
+				t.Fatalf("got %d moons, want 1: %+v", len(got.Moons), got.Moons)
+			}
+			var moon map[string]MoonProductData
+			for _, m := range got.Moons {
+				moon = m
+			}
+
+			data, ok := moon[product]
+			if !ok {
+				t.Fatalf("missing product %q in %+v", product, moon)
+			}
+
+			want := MoonProductData{
+				Quantity:      "0.69",
+				OreTypeID:     "22",
+				SolarSystemID: "30000142",
+				PlanetID:      "40000001",
+				MoonID:        "40000002",
+			}
+			if data != want {
+				t.Errorf("got %+v, want %+v", data, want)
+			}
+		})
+	}
+}
+
+func TestParseMoons_ScanDate(t *testing.T) {
+	got, err := ParseMoons(readFixture(t, "english.txt"))
+	if err != nil {
+		t.Fatalf("ParseMoons: %v", err)
+	}
+
+	want := time.Date(2024, 5, 11, 14, 3, 22, 0, time.UTC)
+	if !got.ScannedAt.Equal(want) {
+		t.Errorf("ScannedAt = %v, want %v", got.ScannedAt, want)
+	}
+}
+
+func TestParseMoons_Compact(t *testing.T) {
+	got, err := ParseMoons(readFixture(t, "compact.txt"))
+	if err != nil {
+		t.Fatalf("ParseMoons: %v", err)
+	}
+
+	data, ok := got.Moons[englishMoonName]["Flawless Arkonor"]
+	if !ok {
+		t.Fatalf("missing product in %+v", got.Moons)
+	}
+
+	want := MoonProductData{
+		Quantity: "0.69",
+		PlanetID: "40000001",
+		MoonID:   "40000002",
+	}
+	if data != want {
+		t.Errorf("got %+v, want %+v", data, want)
+	}
+}
+
+func TestParseMoons_InvalidQuantity(t *testing.T) {
+	_, err := ParseMoons(readFixture(t, "malformed.txt"))
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got %T: %v", err, err)
+	}
+	if parseErr.Line != 3 {
+		t.Errorf("Line = %d, want 3", parseErr.Line)
+	}
+}
+
+func TestLooksLikeScan(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{"english header", readFixture(t, "english.txt"), true},
+		{"japanese header", readFixture(t, "japanese.txt"), true},
+		{"unrelated text", "just some clipboard text\nwith multiple lines", false},
+		{"empty", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := LooksLikeScan(c.in); got != c.want {
+				t.Errorf("LooksLikeScan(%q) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends each scan as a line of JSON to a file, rotating it once
+// it grows past MaxBytes (0 disables rotation).
+type FileSink struct {
+	Path     string
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+// NewFileSink builds a FileSink. maxBytes of 0 disables rotation.
+func NewFileSink(path string, maxBytes int64) *FileSink {
+	return &FileSink{Path: path, MaxBytes: maxBytes}
+}
+
+// Submit implements Sink.
+func (s *FileSink) Submit(ctx context.Context, scan MoonScan) error {
+	line, err := json.Marshal(scan)
+	if err != nil {
+		return fmt.Errorf("sink: marshaling moon scan: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.rotateIfNeeded(int64(len(line))); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("sink: opening %s: %w", s.Path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("sink: writing %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// rotateIfNeeded renames Path to Path+".1" if writing an additional
+// nextWrite bytes would push it past MaxBytes, overwriting any previous
+// ".1" file. Callers must hold s.mu.
+func (s *FileSink) rotateIfNeeded(nextWrite int64) error {
+	if s.MaxBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("sink: stat %s: %w", s.Path, err)
+	}
+	if info.Size()+nextWrite <= s.MaxBytes {
+		return nil
+	}
+	if err := os.Rename(s.Path, s.Path+".1"); err != nil {
+		return fmt.Errorf("sink: rotating %s: %w", s.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPSink POSTs each scan as JSON to Endpoint, retrying on 429 and 5xx
+// responses with exponential backoff and jitter, honoring Retry-After when
+// the server sends one.
+type HTTPSink struct {
+	Endpoint string
+	Token    string
+	Client   *http.Client
+
+	// MaxAttempts caps how many times Submit will try before giving up.
+	// Zero means use the default of 5.
+	MaxAttempts int
+}
+
+// NewHTTPSink builds an HTTPSink. token may be empty to skip the
+// Authorization header.
+func NewHTTPSink(endpoint, token string, client *http.Client) *HTTPSink {
+	return &HTTPSink{Endpoint: endpoint, Token: token, Client: client}
+}
+
+// Submit implements Sink.
+func (s *HTTPSink) Submit(ctx context.Context, scan MoonScan) error {
+	payload, err := json.Marshal(scan)
+	if err != nil {
+		return fmt.Errorf("sink: marshaling moon scan: %w", err)
+	}
+
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		retryAfter, err := s.attempt(ctx, payload)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*nonRetryableError); ok {
+			return fmt.Errorf("sink: http %s: %w", s.Endpoint, err)
+		}
+		lastErr = err
+		if retryAfter > 0 {
+			select {
+			case <-time.After(retryAfter):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return fmt.Errorf("sink: http %s: giving up after %d attempts: %w", s.Endpoint, maxAttempts, lastErr)
+}
+
+// attempt makes one POST. retryAfter is non-zero when the response asked us
+// to wait a specific duration before trying again.
+func (s *HTTPSink) attempt(ctx context.Context, payload []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("POST: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("status %s", resp.Status)
+	}
+	// Any other status (4xx) isn't going to succeed on retry.
+	return 0, &nonRetryableError{status: resp.Status}
+}
+
+type nonRetryableError struct {
+	status string
+}
+
+func (e *nonRetryableError) Error() string {
+	return fmt.Sprintf("status %s", e.status)
+}
+
+// backoff returns an exponential delay with jitter for the given attempt
+// number (0-indexed): a random duration between half and all of 200ms,
+// 400ms, 800ms, ... with that ceiling capped at 10s.
+func backoff(attempt int) time.Duration {
+	const base = 200 * time.Millisecond
+	const max = 10 * time.Second
+
+	d := base << attempt
+	if d > max || d <= 0 {
+		d = max
+	}
+	jitter := time.Duration(rand.Int63n(int64(d) / 2))
+	return d/2 + jitter
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date. Zero is returned if it's missing or malformed.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+package sink
+
+import (
+	"context"
+	"errors"
+)
+
+// MultiSink fans a single scan out to every sink it wraps, attempting all
+// of them even if one fails.
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink builds a MultiSink over sinks.
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// Submit implements Sink.
+func (m *MultiSink) Submit(ctx context.Context, scan MoonScan) error {
+	var errs []error
+	for _, s := range m.sinks {
+		if err := s.Submit(ctx, scan); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
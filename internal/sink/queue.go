@@ -0,0 +1,170 @@
+package sink
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var queueBucket = []byte("queue")
+
+// QueueingSink wraps another Sink and persists scans it couldn't deliver to
+// a bbolt file, retrying them in the background until they succeed. This is
+// what lets goosniffer keep capturing scans while the machine is offline
+// (e.g. between docks) and upload them once connectivity returns.
+type QueueingSink struct {
+	inner        Sink
+	db           *bbolt.DB
+	maxQueueSize int
+	dropOldest   bool
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewQueueingSink opens (creating if necessary) a bbolt file at dbPath to
+// back the offline queue for inner. maxQueueSize of 0 means unbounded. When
+// the queue is full, dropOldest controls whether the oldest queued scan is
+// evicted to make room (true) or the new scan is rejected (false).
+func NewQueueingSink(inner Sink, dbPath string, maxQueueSize int, dropOldest bool) (*QueueingSink, error) {
+	db, err := bbolt.Open(dbPath, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("sink: opening queue db %s: %w", dbPath, err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(queueBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sink: preparing queue db %s: %w", dbPath, err)
+	}
+
+	q := &QueueingSink{
+		inner:        inner,
+		db:           db,
+		maxQueueSize: maxQueueSize,
+		dropOldest:   dropOldest,
+		closeCh:      make(chan struct{}),
+	}
+	q.wg.Add(1)
+	go q.drainLoop()
+	return q, nil
+}
+
+// Submit implements Sink. It delivers immediately when possible, and only
+// falls back to the on-disk queue when inner.Submit fails.
+func (q *QueueingSink) Submit(ctx context.Context, scan MoonScan) error {
+	if err := q.inner.Submit(ctx, scan); err == nil {
+		return nil
+	}
+	return q.enqueue(scan)
+}
+
+// Close stops the background retry loop and closes the queue db. Any scans
+// still queued remain on disk for the next run.
+func (q *QueueingSink) Close() error {
+	q.closeOnce.Do(func() { close(q.closeCh) })
+	q.wg.Wait()
+	return q.db.Close()
+}
+
+func (q *QueueingSink) enqueue(scan MoonScan) error {
+	payload, err := json.Marshal(scan)
+	if err != nil {
+		return fmt.Errorf("sink: marshaling queued scan: %w", err)
+	}
+
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+
+		if q.maxQueueSize > 0 && b.Stats().KeyN >= q.maxQueueSize {
+			if !q.dropOldest {
+				return fmt.Errorf("sink: offline queue full (%d scans)", q.maxQueueSize)
+			}
+			if k, _ := b.Cursor().First(); k != nil {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(seq), payload)
+	})
+}
+
+// drainLoop periodically retries queued scans against inner until they
+// succeed or Close is called.
+func (q *QueueingSink) drainLoop() {
+	defer q.wg.Done()
+
+	const drainInterval = 30 * time.Second
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.closeCh:
+			return
+		case <-ticker.C:
+			q.flush()
+		}
+	}
+}
+
+// flush delivers queued scans oldest-first, stopping at the first one that
+// still fails (we're presumably still offline) so it's retried next tick.
+func (q *QueueingSink) flush() {
+	for {
+		key, payload := q.peek()
+		if key == nil {
+			return
+		}
+
+		var scan MoonScan
+		if err := json.Unmarshal(payload, &scan); err != nil {
+			// Can't do anything useful with a corrupt entry; drop it so it
+			// doesn't block everything behind it forever.
+			q.delete(key)
+			continue
+		}
+
+		if err := q.inner.Submit(context.Background(), scan); err != nil {
+			return
+		}
+		q.delete(key)
+	}
+}
+
+func (q *QueueingSink) peek() (key, payload []byte) {
+	q.db.View(func(tx *bbolt.Tx) error {
+		k, v := tx.Bucket(queueBucket).Cursor().First()
+		if k != nil {
+			key = append([]byte(nil), k...)
+			payload = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return key, payload
+}
+
+func (q *QueueingSink) delete(key []byte) {
+	q.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(queueBucket).Delete(key)
+	})
+}
+
+func sequenceKey(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
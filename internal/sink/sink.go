@@ -0,0 +1,27 @@
+// Package sink delivers parsed moon scans somewhere useful: an HTTP API, a
+// local file, stdout, or some combination of those, with optional offline
+// queueing for when none of them are reachable.
+package sink
+
+import (
+	"context"
+
+	"github.com/Test-Alliance-Please-Ignore/goosniffer/internal/moonparse"
+)
+
+// MoonScan is the payload a Sink is asked to deliver. ScanID is the
+// dedupe.Hash of the scan's source text, included so the backend can
+// idempotently upsert instead of creating duplicate rows when the same
+// scan is (re-)submitted, e.g. after an offline-queue retry. MoonProducts
+// is embedded so its fields (including scanned_at) marshal at the top
+// level alongside scan_id.
+type MoonScan struct {
+	ScanID string `json:"scan_id"`
+	moonparse.MoonProducts
+}
+
+// Sink delivers a MoonScan. Implementations should treat ctx cancellation
+// as a reason to give up early, not as a reason to silently drop the scan.
+type Sink interface {
+	Submit(ctx context.Context, scan MoonScan) error
+}
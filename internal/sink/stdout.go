@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// StdoutSink writes each scan as a line of JSON to Writer (normally
+// os.Stdout).
+type StdoutSink struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// NewStdoutSink builds a StdoutSink writing to w.
+func NewStdoutSink(w io.Writer) *StdoutSink {
+	return &StdoutSink{Writer: w}
+}
+
+// Submit implements Sink.
+func (s *StdoutSink) Submit(ctx context.Context, scan MoonScan) error {
+	line, err := json.Marshal(scan)
+	if err != nil {
+		return fmt.Errorf("sink: marshaling moon scan: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = fmt.Fprintf(s.Writer, "%s\n", line)
+	return err
+}
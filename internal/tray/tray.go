@@ -0,0 +1,67 @@
+// Package tray shows goosniffer's status in the OS notification area and
+// lets the user pause capture, re-send the last scan, or jump to the log
+// folder without needing the console window. The real implementation only
+// builds with the "tray" build tag, since github.com/getlantern/systray
+// pulls in platform UI toolkits we don't want to force on headless builds;
+// without the tag, Run returns a Handle that does nothing.
+package tray
+
+import "time"
+
+// Status is a point-in-time snapshot shown in the tray's tooltip and menu.
+type Status struct {
+	Listening      bool
+	LastScanAt     time.Time
+	LastScanMoons  int
+	UploadSuccess  int
+	UploadFailures int
+}
+
+// Actions lets the tray ask the caller to do something in response to a
+// menu click. The caller's main loop should select on these alongside its
+// other channels for as long as the Handle is alive.
+type Actions struct {
+	TogglePause chan struct{}
+	ResendLast  chan struct{}
+	OpenLogDir  chan struct{}
+	Quit        chan struct{}
+}
+
+// Handle is returned by Run. Callers push status updates to it as the
+// application's state changes and call Quit on shutdown.
+type Handle struct {
+	updates chan Status
+	actions Actions
+	quit    chan struct{}
+
+	// quitFn additionally tears down the real tray icon, when there is one.
+	quitFn func()
+}
+
+// Update pushes a new Status for the tray to display. Safe to call whether
+// or not the tray is actually enabled.
+func (h *Handle) Update(s Status) {
+	select {
+	case h.updates <- s:
+	case <-h.quit:
+	}
+}
+
+// Actions returns the channels the tray sends menu clicks on.
+func (h *Handle) Actions() Actions {
+	return h.actions
+}
+
+// Quit tears down the tray icon, if one was created. Safe to call more than
+// once.
+func (h *Handle) Quit() {
+	select {
+	case <-h.quit:
+		return
+	default:
+		close(h.quit)
+	}
+	if h.quitFn != nil {
+		h.quitFn()
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !tray
+
+package tray
+
+// Run returns a Handle that discards every Update and never fires an
+// Action; this build wasn't compiled with the "tray" tag, so there's no
+// icon to show. enabled and initial are accepted but unused so callers
+// don't need a build-tag switch of their own.
+func Run(enabled bool, initial Status) (*Handle, error) {
+	h := &Handle{
+		updates: make(chan Status),
+		actions: Actions{
+			TogglePause: make(chan struct{}),
+			ResendLast:  make(chan struct{}),
+			OpenLogDir:  make(chan struct{}),
+			Quit:        make(chan struct{}),
+		},
+		quit: make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case <-h.updates:
+			case <-h.quit:
+				return
+			}
+		}
+	}()
+
+	return h, nil
+}
@@ -0,0 +1,126 @@
+//go:build tray
+
+package tray
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getlantern/systray"
+)
+
+// Run starts the tray icon when enabled is true. Build with -tags tray to
+// link in github.com/getlantern/systray; without it, see tray_disabled.go.
+func Run(enabled bool, initial Status) (*Handle, error) {
+	h := &Handle{
+		updates: make(chan Status),
+		actions: Actions{
+			TogglePause: make(chan struct{}),
+			ResendLast:  make(chan struct{}),
+			OpenLogDir:  make(chan struct{}),
+			Quit:        make(chan struct{}),
+		},
+		quit: make(chan struct{}),
+	}
+
+	if !enabled {
+		go func() {
+			for {
+				select {
+				case <-h.updates:
+				case <-h.quit:
+					return
+				}
+			}
+		}()
+		return h, nil
+	}
+
+	h.quitFn = systray.Quit
+
+	ready := make(chan struct{})
+	go systray.Run(func() {
+		onReady(h, initial)
+		close(ready)
+	}, func() {})
+
+	<-ready
+	return h, nil
+}
+
+func onReady(h *Handle, initial Status) {
+	systray.SetTitle("goosniffer")
+	systray.SetTooltip("goosniffer moon scan uploader")
+
+	mStatus := systray.AddMenuItem("", "current state")
+	mStatus.Disable()
+	mLastScan := systray.AddMenuItem("", "most recent scan")
+	mLastScan.Disable()
+	mUploads := systray.AddMenuItem("", "upload counters")
+	mUploads.Disable()
+
+	systray.AddSeparator()
+	mPause := systray.AddMenuItem("Pause capture", "stop processing clipboard changes")
+	mResend := systray.AddMenuItem("Re-send last scan", "submit the last parsed scan again")
+	mOpenLog := systray.AddMenuItem("Open log folder", "open the offline queue's folder, or the working directory if there is no queue")
+	systray.AddSeparator()
+	mQuit := systray.AddMenuItem("Quit", "stop goosniffer entirely, not just the tray icon")
+
+	render := func(s Status) {
+		state := "Listening"
+		pauseLabel := "Pause capture"
+		if !s.Listening {
+			state = "Paused"
+			pauseLabel = "Resume capture"
+		}
+		mStatus.SetTitle(fmt.Sprintf("Status: %s", state))
+		mPause.SetTitle(pauseLabel)
+
+		if s.LastScanAt.IsZero() {
+			mLastScan.SetTitle("Last scan: none yet")
+		} else {
+			mLastScan.SetTitle(fmt.Sprintf("Last scan: %s (%d moons)", s.LastScanAt.Format(time.Kitchen), s.LastScanMoons))
+		}
+		mUploads.SetTitle(fmt.Sprintf("Uploads: %d ok, %d failed", s.UploadSuccess, s.UploadFailures))
+	}
+	render(initial)
+
+	go func() {
+		for {
+			select {
+			case s := <-h.updates:
+				render(s)
+			case <-mPause.ClickedCh:
+				select {
+				case h.actions.TogglePause <- struct{}{}:
+				case <-h.quit:
+					return
+				}
+			case <-mResend.ClickedCh:
+				select {
+				case h.actions.ResendLast <- struct{}{}:
+				case <-h.quit:
+					return
+				}
+			case <-mOpenLog.ClickedCh:
+				select {
+				case h.actions.OpenLogDir <- struct{}{}:
+				case <-h.quit:
+					return
+				}
+			case <-mQuit.ClickedCh:
+				// Forward to the caller's main loop rather than tearing
+				// down just the icon here: a Quit click should stop the
+				// whole app (watcher, queue drain, uploader), and only the
+				// caller knows how to do that.
+				select {
+				case h.actions.Quit <- struct{}{}:
+				case <-h.quit:
+					return
+				}
+			case <-h.quit:
+				return
+			}
+		}
+	}()
+}